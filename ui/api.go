@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dominichamon/hive"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/context"
+
+	"github.com/dominichamon/hive/ui/log"
+	pb "github.com/dominichamon/hive/proto"
+)
+
+// writeJSON marshals v as the response body, setting the appropriate
+// content type and status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.L.Error(err, "failed to encode json response")
+	}
+}
+
+// apiListWorkers handles GET /api/v1/workers.
+func apiListWorkers(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, worker.Snapshot())
+}
+
+// apiGetWorker handles GET /api/v1/workers/{id}.
+func apiGetWorker(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+
+	for _, s := range worker.Snapshot() {
+		if s.Id == id {
+			writeJSON(w, http.StatusOK, s)
+			return
+		}
+	}
+	http.NotFound(w, req)
+}
+
+// apiGetWorkerStatus handles GET /api/v1/workers/{id}/status.
+func apiGetWorkerStatus(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+
+	statusMu.RLock()
+	stat, ok := status[id]
+	statusMu.RUnlock()
+
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	writeJSON(w, http.StatusOK, stat)
+}
+
+// apiRefreshWorker handles POST /api/v1/workers/{id}/refresh, forcing an
+// immediate Status RPC rather than waiting for the next scheduled poll.
+func apiRefreshWorker(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+
+	var target *hive.Worker
+	for _, s := range worker.Snapshot() {
+		if s.Id == id {
+			target = s
+			break
+		}
+	}
+	if target == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	rctx, cancel := context.WithTimeout(context.Background(), *statusTimeout)
+	defer cancel()
+
+	stat, err := target.Client.Status(rctx, &pb.StatusRequest{})
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	recordStatus(id, stat)
+	writeJSON(w, http.StatusOK, stat)
+}
+
+// newRouter builds the HTTP router serving both the HTML Index and the
+// versioned JSON API.
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/", Index).Methods(http.MethodGet)
+	r.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	r.HandleFunc("/login", loginHandler).Methods(http.MethodPost)
+	r.HandleFunc("/jobs", jobsIndexHandler).Methods(http.MethodGet)
+	r.HandleFunc("/jobs/{id}", jobDetailHandler).Methods(http.MethodGet)
+
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/workers", apiListWorkers).Methods(http.MethodGet)
+	api.HandleFunc("/workers/{id}", apiGetWorker).Methods(http.MethodGet)
+	api.HandleFunc("/workers/{id}/status", apiGetWorkerStatus).Methods(http.MethodGet)
+	api.Handle("/workers/{id}/refresh", requireRole(RoleOperator)(http.HandlerFunc(apiRefreshWorker))).Methods(http.MethodPost)
+	api.Handle("/refresh", requireRole(RoleViewer)(http.HandlerFunc(refreshTokenHandler))).Methods(http.MethodPost)
+	api.Handle("/jobs", requireRole(RoleOperator)(http.HandlerFunc(apiSubmitJob))).Methods(http.MethodPost)
+
+	return r
+}
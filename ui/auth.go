@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/net/context"
+)
+
+var (
+	jwtSecret        = flag.String("jwt_secret", "", "Secret used to sign operator JWTs")
+	jwtIssuer        = flag.String("jwt_issuer", "hive-ui", "Issuer claim for operator JWTs")
+	operatorPassword = flag.String("operator_password", "", "Shared password required to obtain an operator JWT via /login")
+)
+
+// tokenTTL is how long an issued JWT remains valid before it must be
+// refreshed via /login or /api/v1/refresh.
+const tokenTTL = 3 * time.Hour
+
+// Role is the access level carried in a token's claims.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+)
+
+// allows reports whether a token bearing role r may access a route that
+// requires need.
+func (r Role) allows(need Role) bool {
+	if need == RoleViewer {
+		return true
+	}
+	return r == RoleOperator
+}
+
+// claims are the custom JWT claims issued by /login.
+type claims struct {
+	Username string `json:"username"`
+	Role     Role   `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type claimsKey struct{}
+
+// issueToken signs a token for username/role, valid for tokenTTL.
+func issueToken(username string, role Role) (string, error) {
+	now := time.Now()
+	c := claims{
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    *jwtIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(*jwtSecret))
+}
+
+// parseToken validates tok and returns its claims.
+func parseToken(tok string) (*claims, error) {
+	c := &claims{}
+	_, err := jwt.ParseWithClaims(tok, c, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(*jwtSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loginHandler handles POST /login, issuing a viewer token for any
+// username and an operator token when password matches --operator_password.
+func loginHandler(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	username := req.FormValue("username")
+	if username == "" {
+		handleError(w, http.StatusBadRequest, fmt.Errorf("username is required"))
+		return
+	}
+
+	role := RoleViewer
+	if pw := req.FormValue("password"); *operatorPassword != "" && pw == *operatorPassword {
+		role = RoleOperator
+	}
+
+	token, err := issueToken(username, role)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"token": token, "role": string(role)})
+}
+
+// refreshTokenHandler handles POST /api/v1/refresh, re-issuing a fresh
+// token for the caller's existing username/role.
+func refreshTokenHandler(w http.ResponseWriter, req *http.Request) {
+	c, ok := req.Context().Value(claimsKey{}).(*claims)
+	if !ok {
+		handleError(w, http.StatusUnauthorized, fmt.Errorf("missing credentials"))
+		return
+	}
+
+	token, err := issueToken(c.Username, c.Role)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"token": token, "role": string(c.Role)})
+}
+
+// requireRole returns middleware that rejects requests without a valid
+// bearer token carrying at least the given role.
+func requireRole(need Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			auth := req.Header.Get("Authorization")
+			tok := strings.TrimPrefix(auth, "Bearer ")
+			if tok == "" || tok == auth {
+				handleError(w, http.StatusUnauthorized, fmt.Errorf("missing bearer token"))
+				return
+			}
+
+			c, err := parseToken(tok)
+			if err != nil {
+				handleError(w, http.StatusUnauthorized, err)
+				return
+			}
+			if !c.Role.allows(need) {
+				handleError(w, http.StatusForbidden, fmt.Errorf("role %q may not access this route", c.Role))
+				return
+			}
+
+			ctx := context.WithValue(req.Context(), claimsKey{}, c)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func withSecret(t *testing.T, secret string) {
+	t.Helper()
+	prev := *jwtSecret
+	*jwtSecret = secret
+	t.Cleanup(func() { *jwtSecret = prev })
+}
+
+func TestIssueAndParseTokenRoundTrip(t *testing.T) {
+	withSecret(t, "test-secret")
+
+	tok, err := issueToken("alice", RoleOperator)
+	if err != nil {
+		t.Fatalf("issueToken: %s", err)
+	}
+
+	c, err := parseToken(tok)
+	if err != nil {
+		t.Fatalf("parseToken: %s", err)
+	}
+	if c.Username != "alice" || c.Role != RoleOperator {
+		t.Errorf("parsed claims = %+v, want username=alice role=operator", c)
+	}
+}
+
+func TestParseTokenWrongSecret(t *testing.T) {
+	withSecret(t, "test-secret")
+	tok, err := issueToken("alice", RoleViewer)
+	if err != nil {
+		t.Fatalf("issueToken: %s", err)
+	}
+
+	*jwtSecret = "a-different-secret"
+	if _, err := parseToken(tok); err == nil {
+		t.Error("parseToken with wrong secret: got nil error, want error")
+	}
+}
+
+func TestParseTokenExpired(t *testing.T) {
+	withSecret(t, "test-secret")
+
+	c := claims{
+		Username: "alice",
+		Role:     RoleViewer,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * tokenTTL)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-tokenTTL)),
+		},
+	}
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(*jwtSecret))
+	if err != nil {
+		t.Fatalf("signing expired token: %s", err)
+	}
+
+	if _, err := parseToken(tok); err == nil {
+		t.Error("parseToken with expired token: got nil error, want error")
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	cases := []struct {
+		have, need Role
+		want       bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleOperator, RoleViewer, true},
+		{RoleOperator, RoleOperator, true},
+		{RoleViewer, RoleOperator, false},
+	}
+	for _, c := range cases {
+		if got := c.have.allows(c.need); got != c.want {
+			t.Errorf("Role(%q).allows(%q) = %v, want %v", c.have, c.need, got, c.want)
+		}
+	}
+}
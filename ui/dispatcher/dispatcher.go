@@ -0,0 +1,87 @@
+// Package dispatcher selects a worker to run a unit of work and executes it.
+package dispatcher
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dominichamon/hive"
+	"golang.org/x/net/context"
+
+	pb "github.com/dominichamon/hive/proto"
+)
+
+// Candidate is a worker eligible for dispatch, along with the free RAM it
+// last reported.
+type Candidate struct {
+	Worker  *hive.Worker
+	FreeRam uint64
+}
+
+// Strategy picks one of a set of candidate workers to run a job.
+type Strategy interface {
+	Select(candidates []Candidate) (*hive.Worker, error)
+}
+
+// RoundRobin cycles through candidates in the order they're given.
+type RoundRobin struct {
+	mu   sync.Mutex
+	next int
+}
+
+func (r *RoundRobin) Select(candidates []Candidate) (*hive.Worker, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no candidate workers")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := candidates[r.next%len(candidates)].Worker
+	r.next++
+	return w, nil
+}
+
+// LeastLoaded picks the candidate with the most free RAM.
+type LeastLoaded struct{}
+
+func (LeastLoaded) Select(candidates []Candidate) (*hive.Worker, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no candidate workers")
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.FreeRam > best.FreeRam {
+			best = c
+		}
+	}
+	return best.Worker, nil
+}
+
+// Result is the outcome of running a job on a worker.
+type Result struct {
+	Stdout     string
+	Stderr     string
+	ExitStatus int32
+}
+
+// Dispatch selects a worker from candidates using strategy and runs cmd/args
+// on it via the worker's Job RPC.
+func Dispatch(ctx context.Context, strategy Strategy, candidates []Candidate, cmd string, args []string) (*hive.Worker, *Result, error) {
+	w, err := strategy.Select(candidates)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := w.Client.Job(ctx, &pb.JobRequest{Command: cmd, Args: args})
+	if err != nil {
+		return w, nil, err
+	}
+
+	return w, &Result{
+		Stdout:     resp.Stdout,
+		Stderr:     resp.Stderr,
+		ExitStatus: resp.ExitStatus,
+	}, nil
+}
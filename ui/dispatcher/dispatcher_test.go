@@ -0,0 +1,60 @@
+package dispatcher
+
+import (
+	"testing"
+
+	"github.com/dominichamon/hive"
+)
+
+func TestRoundRobinSelect(t *testing.T) {
+	candidates := []Candidate{
+		{Worker: &hive.Worker{Id: "a"}},
+		{Worker: &hive.Worker{Id: "b"}},
+		{Worker: &hive.Worker{Id: "c"}},
+	}
+
+	rr := &RoundRobin{}
+	var got []string
+	for i := 0; i < len(candidates)*2; i++ {
+		w, err := rr.Select(candidates)
+		if err != nil {
+			t.Fatalf("Select: %s", err)
+		}
+		got = append(got, w.Id)
+	}
+
+	want := []string{"a", "b", "c", "a", "b", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("selection %d = %q, want %q (full sequence %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestRoundRobinSelectNoCandidates(t *testing.T) {
+	if _, err := (&RoundRobin{}).Select(nil); err == nil {
+		t.Error("Select with no candidates: got nil error, want error")
+	}
+}
+
+func TestLeastLoadedSelect(t *testing.T) {
+	candidates := []Candidate{
+		{Worker: &hive.Worker{Id: "a"}, FreeRam: 100},
+		{Worker: &hive.Worker{Id: "b"}, FreeRam: 500},
+		{Worker: &hive.Worker{Id: "c"}, FreeRam: 200},
+	}
+
+	w, err := LeastLoaded{}.Select(candidates)
+	if err != nil {
+		t.Fatalf("Select: %s", err)
+	}
+	if w.Id != "b" {
+		t.Errorf("Select() = %q, want %q (most free RAM)", w.Id, "b")
+	}
+}
+
+func TestLeastLoadedSelectNoCandidates(t *testing.T) {
+	if _, err := (LeastLoaded{}).Select(nil); err == nil {
+		t.Error("Select with no candidates: got nil error, want error")
+	}
+}
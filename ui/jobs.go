@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.etcd.io/bbolt"
+
+	"github.com/dominichamon/hive/ui/dispatcher"
+	"github.com/dominichamon/hive/ui/log"
+)
+
+var dbPath = flag.String("db", "hive.db", "Path to the local job-history database")
+
+var jobsBucket = []byte("jobs")
+
+// JobStatus is the lifecycle state of a dispatched job.
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is a persisted record of a unit of work dispatched to a worker.
+type Job struct {
+	ID       string    `json:"id"`
+	WorkerID string    `json:"worker_id"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	Status   JobStatus `json:"status"`
+
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitStatus int32  `json:"exit_status"`
+	Error      string `json:"error,omitempty"`
+
+	SubmittedAt time.Time `json:"submitted_at"`
+	FinishedAt  time.Time `json:"finished_at,omitempty"`
+}
+
+// jobStore persists Job records to a local bbolt database so job history
+// survives UI restarts.
+type jobStore struct {
+	db *bbolt.DB
+}
+
+func openJobStore(path string) (*jobStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &jobStore{db: db}, nil
+}
+
+func (s *jobStore) put(j *Job) error {
+	buf, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(j.ID), buf)
+	})
+}
+
+func (s *jobStore) get(id string) (*Job, bool) {
+	var j *Job
+	s.db.View(func(tx *bbolt.Tx) error {
+		buf := tx.Bucket(jobsBucket).Get([]byte(id))
+		if buf == nil {
+			return nil
+		}
+		j = &Job{}
+		return json.Unmarshal(buf, j)
+	})
+	return j, j != nil
+}
+
+func (s *jobStore) list() []*Job {
+	var jobs []*Job
+	s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(_, buf []byte) error {
+			j := &Job{}
+			if err := json.Unmarshal(buf, j); err != nil {
+				return err
+			}
+			jobs = append(jobs, j)
+			return nil
+		})
+	})
+	return jobs
+}
+
+var (
+	jobs        *jobStore
+	jobSeq      int64
+	roundRobin  = &dispatcher.RoundRobin{}
+	leastLoaded = dispatcher.LeastLoaded{}
+)
+
+// nextJobID returns a process-wide unique job ID. The sequence is
+// incremented atomically so concurrent submissions never collide, even if
+// they land in the same Unix second.
+func nextJobID() string {
+	seq := atomic.AddInt64(&jobSeq, 1)
+	return fmt.Sprintf("job-%d-%d", time.Now().Unix(), seq)
+}
+
+// candidates builds the dispatcher.Candidate list from the current worker
+// set and their last known status.
+func candidates() []dispatcher.Candidate {
+	ss := worker.Snapshot()
+
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+
+	cs := make([]dispatcher.Candidate, 0, len(ss))
+	for _, s := range ss {
+		var free uint64
+		if st, ok := status[s.Id]; ok {
+			free = st.FreeRam
+		}
+		cs = append(cs, dispatcher.Candidate{Worker: s, FreeRam: free})
+	}
+	return cs
+}
+
+// apiSubmitJob handles POST /api/v1/jobs: dispatches a command to a worker,
+// selected explicitly or by the requested strategy, and persists the
+// resulting Job record.
+func apiSubmitJob(w http.ResponseWriter, req *http.Request) {
+	var in struct {
+		Command  string   `json:"command"`
+		Args     []string `json:"args"`
+		WorkerID string   `json:"worker_id"`
+		Strategy string   `json:"strategy"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		handleError(w, http.StatusBadRequest, err)
+		return
+	}
+	if in.Command == "" {
+		handleError(w, http.StatusBadRequest, fmt.Errorf("command is required"))
+		return
+	}
+
+	cs := candidates()
+	if in.WorkerID != "" {
+		filtered := cs[:0]
+		for _, c := range cs {
+			if c.Worker.Id == in.WorkerID {
+				filtered = append(filtered, c)
+			}
+		}
+		cs = filtered
+	}
+
+	strategy := dispatcher.Strategy(leastLoaded)
+	if in.Strategy == "round_robin" {
+		strategy = roundRobin
+	}
+
+	j := &Job{
+		ID:          nextJobID(),
+		Command:     in.Command,
+		Args:        in.Args,
+		Status:      JobRunning,
+		SubmittedAt: time.Now(),
+	}
+
+	target, result, err := dispatcher.Dispatch(req.Context(), strategy, cs, in.Command, in.Args)
+	if target != nil {
+		j.WorkerID = target.Id
+	}
+	if err != nil {
+		j.Status = JobFailed
+		j.Error = err.Error()
+	} else {
+		j.Status = JobSucceeded
+		j.Stdout = result.Stdout
+		j.Stderr = result.Stderr
+		j.ExitStatus = result.ExitStatus
+	}
+	j.FinishedAt = time.Now()
+
+	if err := jobs.put(j); err != nil {
+		log.L.Error(err, "failed to persist job", "job_id", j.ID)
+	}
+
+	writeJSON(w, http.StatusOK, j)
+}
+
+// jobsIndexHandler handles GET /jobs, rendering the list of known jobs.
+func jobsIndexHandler(w http.ResponseWriter, req *http.Request) {
+	if err := templates.ExecuteTemplate(w, "jobs", jobs.list()); err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// jobDetailHandler handles GET /jobs/{id}, rendering a single job's output.
+func jobDetailHandler(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+
+	j, ok := jobs.get(id)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	if err := templates.ExecuteTemplate(w, "job", j); err != nil {
+		handleError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
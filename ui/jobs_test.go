@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestJobStorePutGetList(t *testing.T) {
+	s, err := openJobStore(filepath.Join(t.TempDir(), "hive.db"))
+	if err != nil {
+		t.Fatalf("openJobStore: %s", err)
+	}
+	t.Cleanup(func() { s.db.Close() })
+
+	j := &Job{ID: "job-1", WorkerID: "w1", Command: "echo", Status: JobSucceeded}
+	if err := s.put(j); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+
+	got, ok := s.get("job-1")
+	if !ok {
+		t.Fatal("get: job not found")
+	}
+	if got.Command != "echo" || got.WorkerID != "w1" {
+		t.Errorf("get returned %+v, want matching echo/w1", got)
+	}
+
+	if _, ok := s.get("no-such-job"); ok {
+		t.Error("get(missing id): got ok=true, want false")
+	}
+
+	if err := s.put(&Job{ID: "job-2", Command: "ls"}); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+
+	list := s.list()
+	if len(list) != 2 {
+		t.Fatalf("list() returned %d jobs, want 2", len(list))
+	}
+}
+
+func TestNextJobIDUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := nextJobID()
+		if seen[id] {
+			t.Fatalf("nextJobID produced duplicate: %s", id)
+		}
+		seen[id] = true
+	}
+}
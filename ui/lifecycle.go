@@ -0,0 +1,251 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dominichamon/hive"
+	"golang.org/x/net/context"
+
+	"github.com/dominichamon/hive/ui/log"
+	pb "github.com/dominichamon/hive/proto"
+)
+
+var (
+	pollInterval  = flag.Duration("poll_interval", 30*time.Second, "How often to poll a worker for status")
+	statusTimeout = flag.Duration("status_timeout", 5*time.Second, "Timeout for a single Status RPC")
+	maxBackoff    = flag.Duration("max_backoff", 5*time.Minute, "Maximum backoff between polls of a failing worker")
+	maxFailures   = flag.Int("max_failures", 5, "Consecutive Status RPC failures before a worker is evicted")
+)
+
+// EventType describes a change in a worker's lifecycle.
+type EventType int
+
+const (
+	// Added fires when a worker is added to the hive.
+	Added EventType = iota
+	// Removed fires when a worker is evicted or explicitly removed.
+	Removed
+	// Unhealthy fires on the first Status RPC failure after a healthy period.
+	Unhealthy
+	// Recovered fires when a previously unhealthy worker responds again.
+	Recovered
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Unhealthy:
+		return "Unhealthy"
+	case Recovered:
+		return "Recovered"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event describes a single lifecycle transition for a worker.
+type Event struct {
+	Type   EventType
+	Worker *hive.Worker
+}
+
+// workerMap owns the set of known workers and runs a per-worker monitor
+// goroutine that polls Status on pollInterval, backing off exponentially on
+// failure and evicting the worker after maxFailures consecutive failures.
+type workerMap struct {
+	mu     sync.RWMutex
+	worker map[string]*hive.Worker
+	cancel map[string]context.CancelFunc
+
+	subsMu sync.Mutex
+	subs   []chan Event
+}
+
+// Subscribe returns a channel on which lifecycle events are published. The
+// channel is buffered; slow subscribers may miss events under heavy churn.
+func (m *workerMap) Subscribe() <-chan Event {
+	ch := make(chan Event, 16)
+
+	m.subsMu.Lock()
+	m.subs = append(m.subs, ch)
+	m.subsMu.Unlock()
+
+	return ch
+}
+
+func (m *workerMap) publish(t EventType, s *hive.Worker) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+
+	for _, ch := range m.subs {
+		select {
+		case ch <- Event{Type: t, Worker: s}:
+		default:
+			log.L.Info("dropping event: subscriber channel full", "event", t.String(), "worker_id", s.Id)
+		}
+	}
+}
+
+// logLifecycleEvents drains ch, logging each lifecycle transition. It is the
+// UI's own consumer of the Subscribe channel; other subsystems (alerting,
+// additional metrics) can subscribe the same way.
+func logLifecycleEvents(ch <-chan Event) {
+	for e := range ch {
+		log.L.Info("worker lifecycle event", "event", e.Type.String(), "worker_id", e.Worker.Id)
+	}
+}
+
+// Add registers s with the hive and starts monitoring it for status.
+func (m *workerMap) Add(ctx context.Context, s *hive.Worker) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	m.mu.Lock()
+	if old, ok := m.worker[s.Id]; ok {
+		log.L.Info("replacing existing worker", "worker_id", old.Id)
+		if oldCancel, ok := m.cancel[s.Id]; ok {
+			oldCancel()
+		}
+	}
+	m.worker[s.Id] = s
+	m.cancel[s.Id] = cancel
+	m.mu.Unlock()
+
+	workersAdded.Inc()
+	m.publish(Added, s)
+
+	go m.monitor(ctx, s)
+}
+
+// Remove evicts the worker with the given id from the hive, stopping its
+// monitor goroutine.
+func (m *workerMap) Remove(id string) error {
+	m.mu.Lock()
+	s, ok := m.worker[id]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("worker %q not found", id)
+	}
+	if cancel, ok := m.cancel[id]; ok {
+		cancel()
+	}
+	delete(m.worker, id)
+	delete(m.cancel, id)
+	m.mu.Unlock()
+
+	workersRemoved.Inc()
+
+	statusMu.Lock()
+	delete(status, id)
+	statusMu.Unlock()
+
+	statusHistory.remove(id)
+	deleteWorkerMetrics(id)
+	m.publish(Removed, s)
+
+	return nil
+}
+
+// owns reports whether s is still the worker registered under its Id,
+// guarding against a monitor goroutine acting on a worker that has since
+// been replaced or removed.
+func (m *workerMap) owns(s *hive.Worker) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cur, ok := m.worker[s.Id]
+	return ok && cur == s
+}
+
+// Snapshot returns a point-in-time copy of the known workers.
+func (m *workerMap) Snapshot() []*hive.Worker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ss := make([]*hive.Worker, 0, len(m.worker))
+	for _, s := range m.worker {
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+// monitor polls s's Status RPC on pollInterval until ctx is cancelled (on
+// Remove) or s is evicted after maxFailures consecutive failures. Failures
+// back off exponentially, capped at maxBackoff.
+func (m *workerMap) monitor(ctx context.Context, s *hive.Worker) {
+	failures := 0
+	unhealthy := false
+
+	for {
+		rctx, cancel := context.WithTimeout(ctx, *statusTimeout)
+		start := time.Now()
+		stat, err := s.Client.Status(rctx, &pb.StatusRequest{})
+		latencyMs := time.Since(start).Milliseconds()
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				// ctx was cancelled out from under us (the worker was
+				// replaced or removed) rather than the RPC genuinely
+				// failing; don't count it against the worker.
+				return
+			}
+
+			failures++
+			statusErrors.WithLabelValues(s.Id).Inc()
+			discoverySuccess.WithLabelValues(s.Id).Set(0)
+			log.L.Error(err, "status rpc failed", "worker_id", s.Id, "rpc", "Status", "latency_ms", latencyMs, "failures", failures, "max_failures", *maxFailures)
+
+			if !unhealthy {
+				unhealthy = true
+				m.publish(Unhealthy, s)
+			}
+
+			if failures >= *maxFailures {
+				if m.owns(s) {
+					log.L.Error(err, "evicting worker after consecutive failures", "worker_id", s.Id, "failures", failures)
+					m.Remove(s.Id)
+				}
+				return
+			}
+		} else {
+			log.L.Info("status rpc succeeded", "worker_id", s.Id, "rpc", "Status", "latency_ms", latencyMs, "free_ram", stat.FreeRam)
+			discoverySuccess.WithLabelValues(s.Id).Set(1)
+			recordStatus(s.Id, stat)
+
+			if unhealthy {
+				unhealthy = false
+				m.publish(Recovered, s)
+			}
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff(failures)):
+		}
+	}
+}
+
+// backoff returns pollInterval on a healthy worker, doubling per consecutive
+// failure up to maxBackoff.
+func backoff(failures int) time.Duration {
+	if failures == 0 {
+		return *pollInterval
+	}
+
+	d := *pollInterval
+	for i := 0; i < failures && d < *maxBackoff; i++ {
+		d *= 2
+	}
+	if d > *maxBackoff {
+		d = *maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestBackoff(t *testing.T) {
+	if got := backoff(0); got != *pollInterval {
+		t.Errorf("backoff(0) = %s, want pollInterval %s", got, *pollInterval)
+	}
+
+	if got := backoff(1); got != 2**pollInterval {
+		t.Errorf("backoff(1) = %s, want %s", got, 2**pollInterval)
+	}
+
+	// Enough consecutive failures should saturate at maxBackoff rather than
+	// overflowing or growing unbounded.
+	if got := backoff(1000); got != *maxBackoff {
+		t.Errorf("backoff(1000) = %s, want maxBackoff %s", got, *maxBackoff)
+	}
+}
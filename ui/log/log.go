@@ -0,0 +1,50 @@
+// Package log provides the structured logger used throughout the ui
+// package, wrapping a zap backend behind the go-logr interface so call
+// sites log key/value pairs instead of printf-style strings.
+package log
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// L is the process-wide logger. It defaults to discarding output until
+// Init is called with flag-parsed format/level.
+var L logr.Logger = logr.Discard()
+
+// Init configures L to log at level (e.g. "debug", "info", "warn", "error")
+// in either "json" or "text" format.
+func Init(format, level string) error {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q: %s", level, err)
+	}
+
+	cfg := zap.NewProductionConfig()
+	if format == "text" {
+		cfg = zap.NewDevelopmentConfig()
+	} else if format != "json" {
+		return fmt.Errorf("invalid log format %q: must be json or text", format)
+	}
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+
+	zl, err := cfg.Build()
+	if err != nil {
+		return err
+	}
+
+	L = zapr.NewLogger(zl)
+	return nil
+}
+
+// Fatal logs err at error level with msg and keysAndValues, then exits the
+// process, mirroring glog.Fatal's behavior for unrecoverable startup errors.
+func Fatal(err error, msg string, keysAndValues ...interface{}) {
+	L.Error(err, msg, keysAndValues...)
+	os.Exit(1)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	pb "github.com/dominichamon/hive/proto"
+)
+
+const (
+	// historyResolution is the minimum wall-clock spacing between kept
+	// samples; readings that arrive sooner update the current bucket in
+	// place instead of being appended as a new one.
+	historyResolution = time.Minute
+
+	// historyLen is the number of buckets kept per worker. At
+	// historyResolution spacing that's the last 60 minutes; polling slower
+	// than historyResolution (e.g. under backoff) covers a longer span.
+	historyLen = 60
+)
+
+var (
+	workerTotalRam = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hive_worker_total_ram_bytes",
+		Help: "Total RAM reported by the worker's last status RPC.",
+	}, []string{"worker_id"})
+
+	workerFreeRam = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hive_worker_free_ram_bytes",
+		Help: "Free RAM reported by the worker's last status RPC.",
+	}, []string{"worker_id"})
+
+	workerLastSeen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hive_worker_last_seen_timestamp_seconds",
+		Help: "Unix timestamp of the last successful status RPC for the worker.",
+	}, []string{"worker_id"})
+
+	discoverySuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hive_worker_discovery_success",
+		Help: "1 if the worker's last periodic status RPC succeeded, 0 otherwise.",
+	}, []string{"worker_id"})
+
+	discoveryAttempts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hive_discovery_attempts_total",
+		Help: "Number of discovery acks received.",
+	})
+
+	statusErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hive_status_rpc_errors_total",
+		Help: "Number of failed Status RPCs, by worker.",
+	}, []string{"worker_id"})
+
+	workersAdded = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hive_workers_added_total",
+		Help: "Number of workers added to the hive.",
+	})
+
+	workersRemoved = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hive_workers_removed_total",
+		Help: "Number of workers removed from the hive.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		workerTotalRam,
+		workerFreeRam,
+		workerLastSeen,
+		discoverySuccess,
+		discoveryAttempts,
+		statusErrors,
+		workersAdded,
+		workersRemoved,
+	)
+}
+
+// deleteWorkerMetrics removes the per-worker gauge/counter series for id so
+// evicted workers don't leak unbounded label cardinality.
+func deleteWorkerMetrics(id string) {
+	workerTotalRam.DeleteLabelValues(id)
+	workerFreeRam.DeleteLabelValues(id)
+	workerLastSeen.DeleteLabelValues(id)
+	discoverySuccess.DeleteLabelValues(id)
+	statusErrors.DeleteLabelValues(id)
+}
+
+// sample is a single point-in-time StatusResponse snapshot.
+type sample struct {
+	at       time.Time
+	totalRam uint64
+	freeRam  uint64
+}
+
+// history is a bounded ring buffer of samples per worker, bucketed to
+// historyResolution, used to render sparkline-style free-RAM trends in the
+// Index page.
+type history struct {
+	mu      sync.RWMutex
+	samples map[string][]sample
+}
+
+func newHistory() *history {
+	return &history{samples: make(map[string][]sample)}
+}
+
+// record appends a sample for id, bucketing to historyResolution so readings
+// that arrive sooner (e.g. at the default 30s poll interval) update the
+// current bucket instead of padding the buffer with sub-minute entries, and
+// trims to the most recent historyLen buckets.
+func (h *history) record(id string, st *pb.StatusResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	s := h.samples[id]
+
+	latest := sample{at: now, totalRam: st.TotalRam, freeRam: st.FreeRam}
+	if len(s) > 0 && now.Sub(s[len(s)-1].at) < historyResolution {
+		// Keep the bucket's start time so the next bucket opens a full
+		// historyResolution later rather than sliding forward with each read.
+		latest.at = s[len(s)-1].at
+		s[len(s)-1] = latest
+		h.samples[id] = s
+		return
+	}
+
+	s = append(s, latest)
+	if len(s) > historyLen {
+		s = s[len(s)-historyLen:]
+	}
+	h.samples[id] = s
+}
+
+// remove discards all recorded samples for id.
+func (h *history) remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.samples, id)
+}
+
+// freeRamSeries returns the recorded free-RAM values for id, oldest first.
+func (h *history) freeRamSeries(id string) []uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	s := h.samples[id]
+	out := make([]uint64, len(s))
+	for i, v := range s {
+		out[i] = v.freeRam
+	}
+	return out
+}
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders a series of values as a single line of unicode block
+// characters, scaled between the series' own min and max.
+func sparkline(values []uint64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+
+	out := make([]rune, len(values))
+	span := max - min
+	for i, v := range values {
+		if span == 0 {
+			out[i] = sparkTicks[0]
+			continue
+		}
+		idx := int(float64(v-min) / float64(span) * float64(len(sparkTicks)-1))
+		out[i] = sparkTicks[idx]
+	}
+	return string(out)
+}
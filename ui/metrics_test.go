@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestSparklineEmpty(t *testing.T) {
+	if got := sparkline(nil); got != "" {
+		t.Errorf("sparkline(nil) = %q, want empty string", got)
+	}
+}
+
+func TestSparklineFlat(t *testing.T) {
+	got := sparkline([]uint64{5, 5, 5})
+	want := string([]rune{sparkTicks[0], sparkTicks[0], sparkTicks[0]})
+	if got != want {
+		t.Errorf("sparkline(flat) = %q, want %q", got, want)
+	}
+}
+
+func TestSparklineRange(t *testing.T) {
+	got := []rune(sparkline([]uint64{0, 50, 100}))
+	if len(got) != 3 {
+		t.Fatalf("sparkline returned %d runes, want 3", len(got))
+	}
+	if got[0] != sparkTicks[0] {
+		t.Errorf("min value rendered as %q, want %q", got[0], sparkTicks[0])
+	}
+	if got[2] != sparkTicks[len(sparkTicks)-1] {
+		t.Errorf("max value rendered as %q, want %q", got[2], sparkTicks[len(sparkTicks)-1])
+	}
+}
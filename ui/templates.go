@@ -0,0 +1,15 @@
+package main
+
+import (
+	"embed"
+	"html/template"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"sparkline": func(id string) string {
+		return sparkline(statusHistory.freeRamSeries(id))
+	},
+}).ParseFS(templateFS, "templates/*.html"))
@@ -5,17 +5,17 @@ import (
 	"flag"
 	"fmt"
 	"html"
-	"html/template"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/dominichamon/hive"
-	"github.com/golang/glog"
 	"golang.org/x/net/context"
 
+	"github.com/dominichamon/hive/ui/log"
 	pb "github.com/dominichamon/hive/proto"
 )
 
@@ -25,42 +25,20 @@ var (
 	addr  = flag.String("addr", "", "The multicast address to use for discovery")
 	dport = flag.Int("dport", 9997, "The port on which to listen for discovery")
 
-	worker workerMap
-	status map[string]*pb.StatusResponse
-)
+	logFormat = flag.String("log_format", "text", "Log output format: json|text")
+	logLevel  = flag.String("log_level", "info", "Minimum log level: debug|info|warn|error")
 
-type workerMap struct {
-	sync.RWMutex
-	worker map[string]*hive.Worker
-}
-
-func (m *workerMap) add(s *hive.Worker) {
-	m.Lock()
-	m.worker[s.Id] = s
-	m.Unlock()
-}
-
-func (m *workerMap) remove(s *hive.Worker) error {
-	m.RLock()
-	defer m.RUnlock()
-	if _, ok := m.worker[s.Id]; !ok {
-		return fmt.Errorf("worker %q not found", s.Id)
-	}
+	worker workerMap
 
-	m.Lock()
-	defer m.Unlock()
-	if _, ok := m.worker[s.Id]; !ok {
-		return fmt.Errorf("worker %q not found", s.Id)
-	}
-	delete(m.worker, s.Id)
+	statusMu sync.RWMutex
+	status   map[string]*pb.StatusResponse
 
-	return nil
-}
+	statusHistory = newHistory()
+)
 
 func init() {
-	worker.Lock()
 	worker.worker = make(map[string]*hive.Worker)
-	worker.Unlock()
+	worker.cancel = make(map[string]context.CancelFunc)
 
 	status = make(map[string]*pb.StatusResponse)
 }
@@ -68,31 +46,13 @@ func init() {
 func handleError(w http.ResponseWriter, code int, err error) {
 	w.WriteHeader(code)
 	fmt.Fprintf(w, "%q", html.EscapeString(err.Error()))
-	glog.Error(err)
+	log.L.Error(err, "request failed", "status", code)
 }
 
 func Index(w http.ResponseWriter, req *http.Request) {
-	t, err := template.New("index").Parse(
-		`<html><body>
-		<table>
-		<thead><th>Id</th><th>IP</th><th>Host</th><th>Total RAM</th><th>Free RAM</th></thead>
-		{{range $id, $status := .}}
-			<tr>
-				<td>{{$id}}</td>
-				<td>{{$status.Ip}}</td>
-				<td>{{$status.Hostname}}</td>
-				<td>{{$status.TotalRam}}</td>
-				<td>{{$status.FreeRam}}</td>
-			</tr>
-		{{end}}
-		</table>
-		</body></html>`)
-	if err != nil {
-		handleError(w, http.StatusInternalServerError, err)
-		return
-	}
-
-	if err = t.Execute(w, status); err != nil {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+	if err := templates.ExecuteTemplate(w, "index", status); err != nil {
 		handleError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -100,78 +60,74 @@ func Index(w http.ResponseWriter, req *http.Request) {
 
 func handleDiscoveryAcks(ctx context.Context, addrs <-chan string) {
 	for saddr := range addrs {
-		glog.Infof("Discovered worker at %s", saddr)
+		discoveryAttempts.Inc()
+		log.L.Info("discovered worker", "addr", saddr)
 
 		host, port, err := net.SplitHostPort(saddr)
 		if err != nil {
-			glog.Error(err)
+			log.L.Error(err, "failed to split discovery address", "addr", saddr)
 			continue
 		}
 
 		p, err := strconv.ParseInt(port, 10, 32)
 		if err != nil {
-			glog.Error(err)
+			log.L.Error(err, "failed to parse discovery port", "addr", saddr)
 			continue
 		}
 
 		s, err := hive.NewWorker(host, int(p))
 		if err != nil {
-			glog.Errorf("Failed to create new worker: %s", err)
+			log.L.Error(err, "failed to create worker", "addr", saddr)
 			continue
 		}
 
-		glog.Infof("Connected to %+v", s)
-		worker.add(s)
-
-		stat, err := s.Client.Status(ctx, &pb.StatusRequest{})
-		if err != nil {
-			glog.Warning(err)
-		}
-		glog.Infof("Status of %s: %+v", s.Id, stat)
-		// TODO: lock
-		status[s.Id] = stat
-
-		// TODO: remove old worker
+		log.L.Info("connected to worker", "worker_id", s.Id, "addr", saddr)
+		worker.Add(ctx, s)
 	}
 }
 
-func updateStatus(ctx context.Context) {
-	for {
-		worker.RLock()
-		ss := make([]*hive.Worker, len(worker.worker))
-		i := 0
-		for _, s := range worker.worker {
-			ss[i] = s
-			i++
-		}
-		worker.RUnlock()
-
-		for _, s := range ss {
-			stat, err := s.Client.Status(ctx, &pb.StatusRequest{})
-			if err != nil {
-				glog.Warningf("Failed to get status for %+v: %s", s, err)
-				continue
-			}
-			glog.Infof("Status of %s: %+v", s.Id, stat)
-			// TODO: lock
-			status[s.Id] = stat
-		}
-
-		time.Sleep(1 * time.Minute)
-	}
+// recordStatus stores the latest status for id, updates its Prometheus
+// gauges, and appends a sample to its history ring buffer.
+func recordStatus(id string, stat *pb.StatusResponse) {
+	statusMu.Lock()
+	status[id] = stat
+	statusMu.Unlock()
+
+	workerTotalRam.WithLabelValues(id).Set(float64(stat.TotalRam))
+	workerFreeRam.WithLabelValues(id).Set(float64(stat.FreeRam))
+	workerLastSeen.WithLabelValues(id).Set(float64(time.Now().Unix()))
+	statusHistory.record(id, stat)
 }
 
 func main() {
 	flag.Parse()
 
+	if err := log.Init(*logFormat, *logLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging flags: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *jwtSecret == "" {
+		fmt.Fprintln(os.Stderr, "--jwt_secret must be set; refusing to sign or validate tokens with an empty key")
+		os.Exit(1)
+	}
+
 	ctx := context.Background()
 
+	var err error
+	jobs, err = openJobStore(*dbPath)
+	if err != nil {
+		log.Fatal(err, "failed to open job store", "path", *dbPath)
+	}
+
+	go logLifecycleEvents(worker.Subscribe())
+
 	go func() {
 		for {
 			addrs := make(chan string)
 			err := hive.Ping(*addr, *dport, addrs)
 			if err != nil {
-				glog.Error(err)
+				log.L.Error(err, "ping failed")
 				goto sleep
 			}
 			handleDiscoveryAcks(ctx, addrs)
@@ -179,9 +135,9 @@ func main() {
 			time.Sleep(5 * time.Minute)
 		}
 	}()
-	go updateStatus(ctx)
 
-	http.HandleFunc("/", Index)
-	glog.Infof("listening on port %d", *port)
-	glog.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
+	log.L.Info("listening", "port", *port)
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), newRouter()); err != nil {
+		log.Fatal(err, "http server exited")
+	}
 }